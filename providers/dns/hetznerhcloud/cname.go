@@ -0,0 +1,52 @@
+package hetznerhcloud
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+)
+
+// maxCNAMEHops bounds how many CNAME indirections are followed before giving
+// up, guarding against resolvers that report a cyclical chain.
+const maxCNAMEHops = 10
+
+// resolveCNAME looks up the immediate CNAME target of fqdn, returning fqdn
+// unchanged when no CNAME record exists.
+func resolveCNAME(fqdn string) (string, error) {
+	cname, err := net.LookupCNAME(fqdn)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return fqdn, nil
+		}
+
+		return "", err
+	}
+
+	return cname, nil
+}
+
+// followCNAMEChain resolves fqdn through successive CNAME indirections using
+// resolve, returning the final, non-CNAME target.
+func followCNAMEChain(resolve func(string) (string, error), fqdn string) (string, error) {
+	current := dns01.ToFqdn(fqdn)
+
+	for i := 0; i < maxCNAMEHops; i++ {
+		target, err := resolve(current)
+		if err != nil {
+			return "", fmt.Errorf("lookup CNAME for %q: %w", current, err)
+		}
+
+		target = dns01.ToFqdn(target)
+		if strings.EqualFold(target, current) {
+			return current, nil
+		}
+
+		current = target
+	}
+
+	return "", fmt.Errorf("CNAME chain for %q exceeds %d hops", fqdn, maxCNAMEHops)
+}
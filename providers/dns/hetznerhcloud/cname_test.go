@@ -0,0 +1,58 @@
+package hetznerhcloud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowCNAMEChain(t *testing.T) {
+	resolve := func(fqdn string) (string, error) {
+		switch fqdn {
+		case "_acme-challenge.example.com.":
+			return "_acme-challenge.intermediate.example.net.", nil
+		case "_acme-challenge.intermediate.example.net.":
+			return "_acme-challenge.final.example.org.", nil
+		default:
+			return fqdn, nil
+		}
+	}
+
+	target, err := followCNAMEChain(resolve, "_acme-challenge.example.com.")
+	require.NoError(t, err)
+	assert.Equal(t, "_acme-challenge.final.example.org.", target)
+}
+
+func TestFollowCNAMEChain_NoCNAME(t *testing.T) {
+	resolve := func(fqdn string) (string, error) {
+		return fqdn, nil
+	}
+
+	target, err := followCNAMEChain(resolve, "example.com.")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com.", target)
+}
+
+func TestFollowCNAMEChain_TooManyHops(t *testing.T) {
+	hops := 0
+	resolve := func(fqdn string) (string, error) {
+		hops++
+		return fqdn + "x.", nil
+	}
+
+	_, err := followCNAMEChain(resolve, "example.com.")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds")
+}
+
+func TestFollowCNAMEChain_ResolveError(t *testing.T) {
+	resolve := func(string) (string, error) {
+		return "", errors.New("boom")
+	}
+
+	_, err := followCNAMEChain(resolve, "example.com.")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
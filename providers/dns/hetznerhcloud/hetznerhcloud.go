@@ -1,23 +1,19 @@
 package hetznerhcloud
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
-	"github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/platform/config/env"
+	"github.com/go-acme/lego/v4/providers/dns/hetznerhcloud/internal"
 )
 
 const (
@@ -30,11 +26,17 @@ const (
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
 	EnvHTTPTimeout        = envNamespace + "HTTP_TIMEOUT"
-
-	defaultBaseURL = "https://api.hetzner.cloud"
-	defaultTTL     = 60
-
-	maxRetries = 3
+	EnvMaxRetries         = envNamespace + "MAX_RETRIES"
+	EnvRetryBackoff       = envNamespace + "RETRY_BACKOFF"
+	EnvFollowCNAME        = envNamespace + "SEQUENTIAL_FOLLOW_CNAME"
+	EnvSequentialInterval = envNamespace + "SEQUENTIAL_INTERVAL"
+	EnvZoneID             = envNamespace + "ZONE_ID"
+	EnvZoneMappings       = envNamespace + "ZONE_MAPPINGS"
+
+	defaultBaseURL      = "https://api.hetzner.cloud"
+	defaultTTL          = 60
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
 )
 
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
@@ -47,6 +49,34 @@ type Config struct {
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	HTTPClient         *http.Client
+
+	// MaxRetries is the number of attempts made for requests that fail with
+	// a 429 or a 5xx response.
+	MaxRetries int
+	// RetryBackoff is the base exponential backoff duration between retries.
+	// It is ignored for 429 responses that carry a Retry-After or
+	// RateLimit-Reset header, which take precedence.
+	RetryBackoff time.Duration
+
+	// FollowCNAME makes Present and CleanUp resolve the CNAME chain of the
+	// challenge's effective FQDN and operate on the resulting target's zone,
+	// so `_acme-challenge` can be delegated to a zone other than the
+	// domain's own.
+	FollowCNAME bool
+
+	// SequentialInterval, when greater than zero, serializes Present and
+	// CleanUp calls that target the same zone and enforces this minimum
+	// delay between consecutive writes to it.
+	SequentialInterval time.Duration
+
+	// ZoneID, when set, short-circuits zone resolution entirely: every
+	// domain is assumed to live in this zone. Useful for tokens scoped to a
+	// single zone, which cannot list zones at all.
+	ZoneID string
+	// ZoneMappings short-circuits zone resolution for the zone names it
+	// contains, keyed by lowercased zone name, avoiding a `GET /v1/zones`
+	// call for tokens with narrowly scoped access.
+	ZoneMappings map[string]string
 }
 
 // NewDefaultConfig returns a default configuration.
@@ -59,9 +89,33 @@ func NewDefaultConfig() *Config {
 		HTTPClient: &http.Client{
 			Timeout: env.GetOrDefaultSecond(EnvHTTPTimeout, 30*time.Second),
 		},
+		MaxRetries:         env.GetOrDefaultInt(EnvMaxRetries, defaultMaxRetries),
+		RetryBackoff:       env.GetOrDefaultSecond(EnvRetryBackoff, defaultRetryBackoff),
+		FollowCNAME:        env.GetOrDefaultBool(EnvFollowCNAME, false),
+		SequentialInterval: env.GetOrDefaultSecond(EnvSequentialInterval, 0),
+		ZoneID:             env.GetOrDefaultString(EnvZoneID, ""),
+		ZoneMappings:       parseZoneMappings(env.GetOrDefaultString(EnvZoneMappings, "")),
 	}
 }
 
+// parseZoneMappings parses a "zone=id,zone=id" string, as used by
+// HCLOUD_ZONE_MAPPINGS, into a lookup keyed by lowercased zone name.
+// Malformed entries are skipped.
+func parseZoneMappings(raw string) map[string]string {
+	mappings := make(map[string]string)
+
+	for _, pair := range strings.Split(raw, ",") {
+		zone, id, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || zone == "" || id == "" {
+			continue
+		}
+
+		mappings[strings.ToLower(strings.TrimSpace(zone))] = strings.TrimSpace(id)
+	}
+
+	return mappings
+}
+
 // NewDNSProvider returns a DNSProvider instance configured from the environment.
 func NewDNSProvider() (*DNSProvider, error) {
 	values, err := env.Get(EnvToken)
@@ -98,29 +152,46 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, fmt.Errorf("hetznerhcloud: %w", err)
 	}
 
+	client := internal.NewClient(config.HTTPClient, baseURL, config.Token)
+	client.SetRetryPolicy(config.MaxRetries, config.RetryBackoff)
+
 	provider := &DNSProvider{
 		config:         config,
-		baseURL:        baseURL,
-		recordIDs:      make(map[string]string),
+		client:         client,
+		recordIDs:      make(map[string][]recordRef),
 		zoneIDs:        make(map[string]string),
 		findZoneByFqdn: dns01.FindZoneByFqdn,
+		resolveCNAME:   resolveCNAME,
+		sequential:     newZoneSerializer(config.SequentialInterval),
 	}
 
 	return provider, nil
 }
 
+// recordRef tracks a TXT record created by Present so CleanUp can remove the
+// one matching the challenge's value. The Hetzner Cloud zone can hold more
+// than one `_acme-challenge` record at a time, e.g. when lego requests a
+// certificate covering both a domain and its wildcard.
+type recordRef struct {
+	id    string
+	value string
+}
+
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
-	config  *Config
-	baseURL *url.URL
+	config *Config
+	client *internal.Client
 
 	recordMu  sync.Mutex
-	recordIDs map[string]string
+	recordIDs map[string][]recordRef
 
 	zoneMu  sync.Mutex
 	zoneIDs map[string]string
 
 	findZoneByFqdn func(string) (string, error)
+	resolveCNAME   func(string) (string, error)
+
+	sequential *zoneSerializer
 }
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
@@ -128,11 +199,23 @@ func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
 	return d.config.PropagationTimeout, d.config.PollingInterval
 }
 
+// Sequential returns the minimum amount of time to wait between consecutive
+// challenges. lego's dns01 solver detects this method structurally and, when
+// present, serializes challenges instead of running them concurrently.
+func (d *DNSProvider) Sequential() time.Duration {
+	return d.config.SequentialInterval
+}
+
 // Present creates a TXT record using the specified parameters.
 func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 	info := dns01.GetChallengeInfo(domain, keyAuth)
 
-	authZone, err := d.findZoneByFqdn(info.EffectiveFQDN)
+	targetFQDN, err := d.resolveTarget(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("hetznerhcloud: %w", err)
+	}
+
+	authZone, err := d.findZoneByFqdn(targetFQDN)
 	if err != nil {
 		return fmt.Errorf("hetznerhcloud: could not find zone for domain %q: %w", domain, err)
 	}
@@ -146,47 +229,26 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 		return err
 	}
 
-	fqdn := dns01.UnFqdn(info.EffectiveFQDN)
-	relativeRecord := fqdn
-	suffix := "." + zoneName
-	fqdnLower := strings.ToLower(fqdn)
-	suffixLower := strings.ToLower(suffix)
+	relativeRecord := toRelativeRecord(targetFQDN, zoneName)
 
-	switch {
-	case strings.EqualFold(fqdn, zoneName):
-		relativeRecord = ""
-	case len(fqdn) > len(suffix) && strings.HasSuffix(fqdnLower, suffixLower):
-		relativeRecord = fqdn[:len(fqdn)-len(suffix)]
-	}
-
-	if relativeRecord == "" {
-		relativeRecord = "_acme-challenge"
-	}
-
-	payload := map[string]any{
-		"name":  relativeRecord,
-		"type":  "TXT",
-		"value": info.Value,
-		"ttl":   d.config.TTL,
-	}
-
-	var response struct {
-		Record struct {
-			ID json.RawMessage `json:"id"`
-		} `json:"record"`
-	}
-
-	if err = d.post(ctx, fmt.Sprintf("/v1/zones/%s/records", zoneID), payload, &response); err != nil {
+	var record internal.Record
+	err = d.sequential.do(ctx, zoneID, func() error {
+		record, err = d.client.CreateRecord(ctx, zoneID, internal.RecordRequest{
+			Name:  relativeRecord,
+			Type:  "TXT",
+			Value: info.Value,
+			TTL:   d.config.TTL,
+		})
 		return err
-	}
-
-	recordID, err := parseIdentifier(response.Record.ID)
+	})
 	if err != nil {
-		return fmt.Errorf("hetznerhcloud: %w", err)
+		return err
 	}
 
+	key := strings.ToLower(info.EffectiveFQDN)
+
 	d.recordMu.Lock()
-	d.recordIDs[strings.ToLower(info.EffectiveFQDN)] = recordID
+	d.recordIDs[key] = append(d.recordIDs[key], recordRef{id: record.ID.String(), value: info.Value})
 	d.recordMu.Unlock()
 
 	return nil
@@ -196,17 +258,20 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	info := dns01.GetChallengeInfo(domain, keyAuth)
 
-	authZone, err := d.findZoneByFqdn(info.EffectiveFQDN)
+	targetFQDN, err := d.resolveTarget(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("hetznerhcloud: %w", err)
+	}
+
+	authZone, err := d.findZoneByFqdn(targetFQDN)
 	if err != nil {
 		return fmt.Errorf("hetznerhcloud: could not find zone for domain %q: %w", domain, err)
 	}
 
 	zoneName := dns01.UnFqdn(authZone)
+	key := strings.ToLower(info.EffectiveFQDN)
 
-	d.recordMu.Lock()
-	recordID, ok := d.recordIDs[strings.ToLower(info.EffectiveFQDN)]
-	d.recordMu.Unlock()
-
+	recordID, ok := d.findRecordID(key, info.Value)
 	if !ok {
 		return nil
 	}
@@ -218,21 +283,72 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 		return err
 	}
 
-	path := fmt.Sprintf("/v1/zones/%s/records/%s", zoneID, recordID)
-	if err := d.delete(ctx, path); err != nil {
+	if err := d.sequential.do(ctx, zoneID, func() error {
+		return d.client.DeleteRecord(ctx, zoneID, recordID)
+	}); err != nil {
 		return err
 	}
 
-	d.recordMu.Lock()
-	delete(d.recordIDs, strings.ToLower(info.EffectiveFQDN))
-	d.recordMu.Unlock()
+	d.removeRecordID(key, recordID)
 
 	return nil
 }
 
+// findRecordID returns the record ID tracked for key whose value matches, if any.
+func (d *DNSProvider) findRecordID(key, value string) (string, bool) {
+	d.recordMu.Lock()
+	defer d.recordMu.Unlock()
+
+	for _, ref := range d.recordIDs[key] {
+		if ref.value == value {
+			return ref.id, true
+		}
+	}
+
+	return "", false
+}
+
+// removeRecordID stops tracking the record identified by id under key.
+func (d *DNSProvider) removeRecordID(key, id string) {
+	d.recordMu.Lock()
+	defer d.recordMu.Unlock()
+
+	refs := d.recordIDs[key]
+	for i, ref := range refs {
+		if ref.id == id {
+			d.recordIDs[key] = append(refs[:i], refs[i+1:]...)
+			break
+		}
+	}
+
+	if len(d.recordIDs[key]) == 0 {
+		delete(d.recordIDs, key)
+	}
+}
+
+// resolveTarget returns the FQDN whose zone the TXT record should be written
+// to: effectiveFQDN unchanged, unless Config.FollowCNAME is set, in which
+// case it is the final target of effectiveFQDN's CNAME chain, allowing
+// `_acme-challenge` to be delegated to another zone.
+func (d *DNSProvider) resolveTarget(effectiveFQDN string) (string, error) {
+	if !d.config.FollowCNAME {
+		return effectiveFQDN, nil
+	}
+
+	return followCNAMEChain(d.resolveCNAME, effectiveFQDN)
+}
+
 func (d *DNSProvider) getZoneID(ctx context.Context, zoneName string) (string, error) {
 	zoneKey := strings.ToLower(zoneName)
 
+	if d.config.ZoneID != "" {
+		return d.config.ZoneID, nil
+	}
+
+	if id, ok := d.config.ZoneMappings[zoneKey]; ok {
+		return id, nil
+	}
+
 	d.zoneMu.Lock()
 	if id, ok := d.zoneIDs[zoneKey]; ok {
 		d.zoneMu.Unlock()
@@ -242,33 +358,14 @@ func (d *DNSProvider) getZoneID(ctx context.Context, zoneName string) (string, e
 
 	page := 1
 	for {
-		query := url.Values{}
-		query.Set("name", zoneName)
-		query.Set("page", strconv.Itoa(page))
-		query.Set("per_page", "50")
-
-		var response struct {
-			Zones []struct {
-				ID   json.RawMessage `json:"id"`
-				Name string          `json:"name"`
-			} `json:"zones"`
-			Meta struct {
-				Pagination struct {
-					NextPage *int `json:"next_page"`
-				} `json:"pagination"`
-			} `json:"meta"`
-		}
-
-		if err := d.get(ctx, "/v1/zones", query, &response); err != nil {
+		response, err := d.client.ListZones(ctx, zoneName, page)
+		if err != nil {
 			return "", err
 		}
 
 		for _, zone := range response.Zones {
 			if strings.EqualFold(zone.Name, zoneName) {
-				id, err := parseIdentifier(zone.ID)
-				if err != nil {
-					return "", fmt.Errorf("hetznerhcloud: %w", err)
-				}
+				id := zone.ID.String()
 
 				d.zoneMu.Lock()
 				d.zoneIDs[zoneKey] = id
@@ -292,108 +389,23 @@ func (d *DNSProvider) getZoneID(ctx context.Context, zoneName string) (string, e
 	return "", fmt.Errorf("hetznerhcloud: zone %q not found", zoneName)
 }
 
-func (d *DNSProvider) get(ctx context.Context, path string, query url.Values, into any) error {
-	return d.do(ctx, http.MethodGet, path, query, nil, into)
-}
-
-func (d *DNSProvider) post(ctx context.Context, path string, payload any, into any) error {
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("hetznerhcloud: %w", err)
-	}
-
-	return d.do(ctx, http.MethodPost, path, nil, body, into)
-}
-
-func (d *DNSProvider) delete(ctx context.Context, path string) error {
-	return d.do(ctx, http.MethodDelete, path, nil, nil, nil)
-}
-
-func (d *DNSProvider) do(ctx context.Context, method, path string, query url.Values, body []byte, into any) error {
-	pathWithQuery := path
-	if len(query) > 0 {
-		pathWithQuery = path + "?" + query.Encode()
-	}
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		req, err := d.newRequest(ctx, method, path, query, body)
-		if err != nil {
-			return err
-		}
-
-		resp, err := d.config.HTTPClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("hetznerhcloud: api request failed: %w", err)
-		}
-
-		data, readErr := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if readErr != nil {
-			return fmt.Errorf("hetznerhcloud: failed to read response: %w", readErr)
-		}
-
-		if resp.StatusCode >= 500 && resp.StatusCode <= 599 {
-			log.Warnf("hetznerhcloud: request %s %s failed with status %s (attempt %d/%d)", method, pathWithQuery, resp.Status, attempt, maxRetries)
-			if attempt == maxRetries {
-				return fmt.Errorf("hetznerhcloud: API request %s %s failed: %s", method, pathWithQuery, resp.Status)
-			}
-			continue
-		}
-
-		if resp.StatusCode >= 400 {
-			message := strings.TrimSpace(string(data))
-			if message == "" {
-				message = resp.Status
-			}
-			return fmt.Errorf("hetznerhcloud: API request %s %s failed: %s", method, pathWithQuery, message)
-		}
-
-		if into != nil && len(data) > 0 {
-			if err := json.Unmarshal(data, into); err != nil {
-				return fmt.Errorf("hetznerhcloud: decode response: %w", err)
-			}
-		}
-
-		return nil
-	}
-
-	return nil
-}
-
-func (d *DNSProvider) newRequest(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Request, error) {
-	endpoint := d.baseURL.ResolveReference(&url.URL{Path: path})
-	if len(query) > 0 {
-		endpoint.RawQuery = query.Encode()
-	}
-
-	var reader io.Reader
-	if body != nil {
-		reader = bytes.NewReader(body)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
-	if err != nil {
-		return nil, fmt.Errorf("hetznerhcloud: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+d.config.Token)
-	req.Header.Set("Accept", "application/json")
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	return req, nil
-}
+// toRelativeRecord computes the record name relative to zoneName, defaulting
+// to "_acme-challenge" when the FQDN is the zone apex.
+func toRelativeRecord(effectiveFQDN, zoneName string) string {
+	fqdn := dns01.UnFqdn(effectiveFQDN)
+	relativeRecord := fqdn
 
-func parseIdentifier(raw json.RawMessage) (string, error) {
-	if len(raw) == 0 {
-		return "", errors.New("identifier missing")
+	suffix := "." + zoneName
+	switch {
+	case strings.EqualFold(fqdn, zoneName):
+		relativeRecord = ""
+	case len(fqdn) > len(suffix) && strings.HasSuffix(strings.ToLower(fqdn), strings.ToLower(suffix)):
+		relativeRecord = fqdn[:len(fqdn)-len(suffix)]
 	}
 
-	trimmed := strings.Trim(string(raw), "\"")
-	if trimmed == "" {
-		return "", errors.New("identifier missing")
+	if relativeRecord == "" {
+		relativeRecord = "_acme-challenge"
 	}
 
-	return trimmed, nil
+	return relativeRecord
 }
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-acme/lego/v4/log"
 	"github.com/stretchr/testify/assert"
@@ -261,6 +263,7 @@ func TestDNSProvider_Present_RecordCreationFailure(t *testing.T) {
 	config.BaseURL = server.URL
 	config.Token = "secret"
 	config.HTTPClient = server.Client()
+	config.RetryBackoff = time.Millisecond
 
 	provider, err := NewDNSProviderConfig(config)
 	require.NoError(t, err)
@@ -330,6 +333,7 @@ func TestDNSProvider_CleanUp_RecordDeletionFailure(t *testing.T) {
 	config.BaseURL = server.URL
 	config.Token = "secret"
 	config.HTTPClient = server.Client()
+	config.RetryBackoff = time.Millisecond
 
 	provider, err := NewDNSProviderConfig(config)
 	require.NoError(t, err)
@@ -345,3 +349,244 @@ func TestDNSProvider_CleanUp_RecordDeletionFailure(t *testing.T) {
 	assert.Equal(t, 3, deleteRequests)
 	assert.True(t, logger.containsSubstring("[WARN]"))
 }
+
+func TestDNSProvider_PresentTwice_CleansUpBothRecords(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"zones": []map[string]any{{
+				"id":   123,
+				"name": "example.com",
+			}},
+			"meta": map[string]any{
+				"pagination": map[string]any{
+					"next_page": nil,
+				},
+			},
+		}))
+	})
+
+	var nextRecordID int
+	deletedRecordIDs := make(map[string]bool)
+
+	mux.HandleFunc("/v1/zones/123/records", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		nextRecordID++
+		id := strconv.Itoa(nextRecordID)
+
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"record": map[string]any{
+				"id":    id,
+				"name":  payload["name"],
+				"type":  payload["type"],
+				"value": payload["value"],
+			},
+		}))
+	})
+
+	mux.HandleFunc("/v1/zones/123/records/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodDelete, r.Method)
+		id := strings.TrimPrefix(r.URL.Path, "/v1/zones/123/records/")
+		deletedRecordIDs[id] = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.BaseURL = server.URL
+	config.Token = "secret"
+	config.HTTPClient = server.Client()
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	provider.findZoneByFqdn = func(string) (string, error) {
+		return "example.com.", nil
+	}
+
+	require.NoError(t, provider.Present("example.com", "token", "keyAuthOne"))
+	require.NoError(t, provider.Present("example.com", "token", "keyAuthTwo"))
+
+	require.NoError(t, provider.CleanUp("example.com", "token", "keyAuthOne"))
+	require.NoError(t, provider.CleanUp("example.com", "token", "keyAuthTwo"))
+
+	assert.Len(t, deletedRecordIDs, 2)
+	assert.True(t, deletedRecordIDs["1"])
+	assert.True(t, deletedRecordIDs["2"])
+}
+
+func TestDNSProvider_PresentAndCleanUp_FollowsCNAME(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "delegated.net", r.URL.Query().Get("name"))
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"zones": []map[string]any{{
+				"id":   999,
+				"name": "delegated.net",
+			}},
+			"meta": map[string]any{
+				"pagination": map[string]any{
+					"next_page": nil,
+				},
+			},
+		}))
+	})
+
+	mux.HandleFunc("/v1/zones/999/records", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "_acme-challenge", payload["name"])
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"record": map[string]any{
+				"id":    "789",
+				"name":  payload["name"],
+				"type":  payload["type"],
+				"value": payload["value"],
+			},
+		}))
+	})
+
+	mux.HandleFunc("/v1/zones/999/records/789", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.BaseURL = server.URL
+	config.Token = "secret"
+	config.HTTPClient = server.Client()
+	config.FollowCNAME = true
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	provider.findZoneByFqdn = func(fqdn string) (string, error) {
+		if fqdn == "_acme-challenge.delegated.net." {
+			return "delegated.net.", nil
+		}
+		return "example.com.", nil
+	}
+
+	provider.resolveCNAME = func(fqdn string) (string, error) {
+		if fqdn == "_acme-challenge.example.com." {
+			return "_acme-challenge.delegated.net.", nil
+		}
+		return fqdn, nil
+	}
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+}
+
+func TestDNSProvider_Present_ZoneIDOverrideSkipsZoneLookup(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("zone lookup should have been skipped")
+	})
+
+	mux.HandleFunc("/v1/zones/999/records", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"record": map[string]any{
+				"id":   "456",
+				"name": "_acme-challenge",
+				"type": "TXT",
+			},
+		}))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.BaseURL = server.URL
+	config.Token = "secret"
+	config.HTTPClient = server.Client()
+	config.ZoneID = "999"
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	provider.findZoneByFqdn = func(string) (string, error) {
+		return "example.com.", nil
+	}
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+}
+
+func TestDNSProvider_Present_ZoneMappingsSkipsZoneLookup(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("zone lookup should have been skipped")
+	})
+
+	mux.HandleFunc("/v1/zones/123/records", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"record": map[string]any{
+				"id":   "456",
+				"name": "_acme-challenge",
+				"type": "TXT",
+			},
+		}))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.BaseURL = server.URL
+	config.Token = "secret"
+	config.HTTPClient = server.Client()
+	config.ZoneMappings = map[string]string{"example.com": "123"}
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	provider.findZoneByFqdn = func(string) (string, error) {
+		return "example.com.", nil
+	}
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+}
+
+func TestParseZoneMappings(t *testing.T) {
+	mappings := parseZoneMappings("example.com=123, Foo.dev=456,malformed,bar.dev=")
+
+	assert.Equal(t, map[string]string{
+		"example.com": "123",
+		"foo.dev":     "456",
+	}, mappings)
+}
@@ -0,0 +1,206 @@
+// Package internal implements a minimal Hetzner Cloud API client scoped to
+// the DNS endpoints needed by the ACME DNS provider.
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/log"
+)
+
+const defaultBaseURL = "https://api.hetzner.cloud"
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
+)
+
+// Client is a Hetzner Cloud API client.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// NewClient creates a new Client. httpClient defaults to http.DefaultClient
+// when nil, and baseURL defaults to the production Hetzner Cloud API.
+func NewClient(httpClient *http.Client, baseURL *url.URL, token string) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if baseURL == nil {
+		baseURL, _ = url.Parse(defaultBaseURL)
+	}
+
+	return &Client{
+		baseURL:      baseURL,
+		httpClient:   httpClient,
+		token:        token,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+}
+
+// SetRetryPolicy overrides the number of retry attempts and the base backoff
+// duration used between retries of 429 and 5xx responses.
+func (c *Client) SetRetryPolicy(maxRetries int, retryBackoff time.Duration) {
+	if maxRetries > 0 {
+		c.maxRetries = maxRetries
+	}
+
+	if retryBackoff > 0 {
+		c.retryBackoff = retryBackoff
+	}
+}
+
+// ListZones returns the zones matching name on the given page.
+func (c *Client) ListZones(ctx context.Context, name string, page int) (ListZonesResponse, error) {
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", "50")
+
+	var response ListZonesResponse
+	if err := c.get(ctx, "/v1/zones", query, &response); err != nil {
+		return ListZonesResponse{}, err
+	}
+
+	return response, nil
+}
+
+// CreateRecord creates a DNS record within zoneID.
+func (c *Client) CreateRecord(ctx context.Context, zoneID string, request RecordRequest) (Record, error) {
+	var response CreateRecordResponse
+	if err := c.post(ctx, fmt.Sprintf("/v1/zones/%s/records", zoneID), request, &response); err != nil {
+		return Record{}, err
+	}
+
+	return response.Record, nil
+}
+
+// DeleteRecord deletes the DNS record identified by recordID within zoneID.
+func (c *Client) DeleteRecord(ctx context.Context, zoneID, recordID string) error {
+	return c.delete(ctx, fmt.Sprintf("/v1/zones/%s/records/%s", zoneID, recordID))
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, into any) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, into)
+}
+
+func (c *Client) post(ctx context.Context, path string, payload, into any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("hetznerhcloud: %w", err)
+	}
+
+	return c.do(ctx, http.MethodPost, path, nil, body, into)
+}
+
+func (c *Client) delete(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body []byte, into any) error {
+	pathWithQuery := path
+	if len(query) > 0 {
+		pathWithQuery = path + "?" + query.Encode()
+	}
+
+	for attempt := 1; attempt <= c.maxRetries; attempt++ {
+		req, err := c.newRequest(ctx, method, path, query, body)
+		if err != nil {
+			return err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("hetznerhcloud: api request failed: %w", err)
+		}
+
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return fmt.Errorf("hetznerhcloud: failed to read response: %w", readErr)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if attempt == c.maxRetries {
+				return fmt.Errorf("hetznerhcloud: API request %s %s failed: %s", method, pathWithQuery, resp.Status)
+			}
+			wait := retryAfter(resp.Header, backoffWithJitter(c.retryBackoff, attempt))
+			log.Warnf("hetznerhcloud: request %s %s rate limited, retrying in %s (attempt %d/%d)", method, pathWithQuery, wait, attempt, c.maxRetries)
+			if err := sleepContext(ctx, wait); err != nil {
+				return fmt.Errorf("hetznerhcloud: %w", err)
+			}
+			continue
+		}
+
+		if resp.StatusCode >= 500 && resp.StatusCode <= 599 {
+			if attempt == c.maxRetries {
+				return fmt.Errorf("hetznerhcloud: API request %s %s failed: %s", method, pathWithQuery, resp.Status)
+			}
+			wait := backoffWithJitter(c.retryBackoff, attempt)
+			log.Warnf("hetznerhcloud: request %s %s failed with status %s, retrying in %s (attempt %d/%d)", method, pathWithQuery, resp.Status, wait, attempt, c.maxRetries)
+			if err := sleepContext(ctx, wait); err != nil {
+				return fmt.Errorf("hetznerhcloud: %w", err)
+			}
+			continue
+		}
+
+		if resp.StatusCode >= 400 {
+			message := strings.TrimSpace(string(data))
+			if message == "" {
+				message = resp.Status
+			}
+			return fmt.Errorf("hetznerhcloud: API request %s %s failed: %s", method, pathWithQuery, message)
+		}
+
+		if into != nil && len(data) > 0 {
+			if err := json.Unmarshal(data, into); err != nil {
+				return fmt.Errorf("hetznerhcloud: decode response: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Request, error) {
+	endpoint := c.baseURL.ResolveReference(&url.URL{Path: path})
+	if len(query) > 0 {
+		endpoint.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("hetznerhcloud: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, mux *http.ServeMux) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := NewClient(server.Client(), baseURL, "secret")
+	client.SetRetryPolicy(3, time.Millisecond)
+
+	return client
+}
+
+func TestClient_ListZones(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "example.com", r.URL.Query().Get("name"))
+		assert.Equal(t, "1", r.URL.Query().Get("page"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"zones": []map[string]any{{
+				"id":   123,
+				"name": "example.com",
+			}},
+			"meta": map[string]any{
+				"pagination": map[string]any{
+					"next_page": nil,
+				},
+			},
+		}))
+	})
+
+	client := newTestClient(t, mux)
+
+	response, err := client.ListZones(context.Background(), "example.com", 1)
+	require.NoError(t, err)
+	require.Len(t, response.Zones, 1)
+	assert.Equal(t, "123", response.Zones[0].ID.String())
+	assert.Equal(t, "example.com", response.Zones[0].Name)
+	assert.Nil(t, response.Meta.Pagination.NextPage)
+}
+
+func TestClient_CreateRecord(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones/123/records", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+
+		var payload RecordRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "_acme-challenge", payload.Name)
+		assert.Equal(t, "TXT", payload.Type)
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"record": map[string]any{
+				"id":    "456",
+				"name":  payload.Name,
+				"type":  payload.Type,
+				"value": payload.Value,
+			},
+		}))
+	})
+
+	client := newTestClient(t, mux)
+
+	record, err := client.CreateRecord(context.Background(), "123", RecordRequest{
+		Name:  "_acme-challenge",
+		Type:  "TXT",
+		Value: "txt-value",
+		TTL:   60,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "456", record.ID.String())
+	assert.Equal(t, "txt-value", record.Value)
+}
+
+func TestClient_DeleteRecord(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones/123/records/456", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.DeleteRecord(context.Background(), "123", "456"))
+}
+
+func TestClient_RetriesOn5xxThenSucceeds(t *testing.T) {
+	mux := http.NewServeMux()
+
+	var attempts int
+
+	mux.HandleFunc("/v1/zones/123/records/456", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.DeleteRecord(context.Background(), "123", "456"))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestClient_RetriesOn429ThenSucceeds(t *testing.T) {
+	mux := http.NewServeMux()
+
+	var attempts int
+
+	mux.HandleFunc("/v1/zones/123/records/456", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.DeleteRecord(context.Background(), "123", "456"))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	mux := http.NewServeMux()
+
+	var attempts int
+
+	mux.HandleFunc("/v1/zones/123/records/456", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client := newTestClient(t, mux)
+
+	err := client.DeleteRecord(context.Background(), "123", "456")
+	require.Error(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestClient_HonorsContextCancellationDuringBackoff(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/zones/123/records/456", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	client := newTestClient(t, mux)
+	client.SetRetryPolicy(3, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// Cancel once the first 502 has been answered and the client is in its
+	// hour-long backoff.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	err := client.DeleteRecord(ctx, "123", "456")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
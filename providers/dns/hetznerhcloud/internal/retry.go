@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryAfter computes how long to wait before retrying a 429 response,
+// preferring the Retry-After header (seconds) and falling back to
+// RateLimit-Reset (a unix timestamp), both of which Hetzner Cloud may send.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	if raw := header.Get("Retry-After"); raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	if raw := header.Get("RateLimit-Reset"); raw != "" {
+		if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			if wait := time.Until(time.Unix(unix, 0)); wait > 0 {
+				return wait
+			}
+		}
+	}
+
+	return fallback
+}
+
+// backoffWithJitter returns an exponential backoff duration for the given
+// attempt (1-indexed), with up to 50% jitter added to avoid thundering-herd
+// retries when many certificates are renewed in parallel.
+func backoffWithJitter(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	backoff := base << (attempt - 1)
+
+	//nolint:gosec // jitter does not need to be cryptographically secure.
+	jitter := time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+
+	return backoff + jitter
+}
+
+// sleepContext waits for d, returning early with ctx.Err() if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
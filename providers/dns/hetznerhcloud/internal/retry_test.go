@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryAfter_RetryAfterHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+
+	assert.Equal(t, 2*time.Second, retryAfter(header, time.Hour))
+}
+
+func TestRetryAfter_RateLimitResetHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("RateLimit-Reset", strconv.FormatInt(time.Now().Add(3*time.Second).Unix(), 10))
+
+	wait := retryAfter(header, time.Hour)
+	assert.Greater(t, wait, time.Duration(0))
+	assert.LessOrEqual(t, wait, 3*time.Second)
+}
+
+func TestRetryAfter_FallsBackWithoutHeaders(t *testing.T) {
+	assert.Equal(t, time.Second, retryAfter(http.Header{}, time.Second))
+}
+
+func TestBackoffWithJitter_GrowsWithAttempt(t *testing.T) {
+	base := 10 * time.Millisecond
+
+	first := backoffWithJitter(base, 1)
+	third := backoffWithJitter(base, 3)
+
+	assert.GreaterOrEqual(t, first, base)
+	assert.GreaterOrEqual(t, third, base*4)
+}
+
+func TestBackoffWithJitter_ZeroBase(t *testing.T) {
+	assert.Equal(t, time.Duration(0), backoffWithJitter(0, 1))
+}
+
+func TestSleepContext_HonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := sleepContext(ctx, time.Minute)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSleepContext_ZeroDurationReturnsImmediately(t *testing.T) {
+	assert.NoError(t, sleepContext(context.Background(), 0))
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ID is a Hetzner Cloud API identifier. The API is inconsistent about
+// whether it serializes identifiers as a JSON number or a JSON string, so ID
+// accepts both and normalizes to a string.
+type ID string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *ID) UnmarshalJSON(data []byte) error {
+	parsed, err := parseIdentifier(data)
+	if err != nil {
+		return err
+	}
+
+	*i = ID(parsed)
+
+	return nil
+}
+
+// String returns the identifier as a string.
+func (i ID) String() string {
+	return string(i)
+}
+
+// Zone is a Hetzner Cloud DNS zone.
+type Zone struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+}
+
+// Record is a DNS record within a zone.
+type Record struct {
+	ID    ID     `json:"id"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	TTL   int    `json:"ttl"`
+}
+
+// RecordRequest is the payload sent to create or update a DNS record.
+type RecordRequest struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	TTL   int    `json:"ttl"`
+}
+
+// Pagination describes the current page of a paginated list response.
+type Pagination struct {
+	Page         int  `json:"page"`
+	PerPage      int  `json:"per_page"`
+	PreviousPage *int `json:"previous_page"`
+	NextPage     *int `json:"next_page"`
+	LastPage     int  `json:"last_page"`
+	TotalEntries int  `json:"total_entries"`
+}
+
+// Meta wraps the pagination metadata attached to list responses.
+type Meta struct {
+	Pagination Pagination `json:"pagination"`
+}
+
+// ListZonesResponse is the response of GET /v1/zones.
+type ListZonesResponse struct {
+	Zones []Zone `json:"zones"`
+	Meta  Meta   `json:"meta"`
+}
+
+// CreateRecordResponse is the response of POST /v1/zones/{id}/records.
+type CreateRecordResponse struct {
+	Record Record `json:"record"`
+}
+
+// parseIdentifier normalizes a Hetzner Cloud identifier, which the API
+// represents inconsistently as either a JSON number or a JSON string.
+func parseIdentifier(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", errors.New("identifier missing")
+	}
+
+	trimmed := strings.Trim(string(raw), `"`)
+	if trimmed == "" {
+		return "", errors.New("identifier missing")
+	}
+
+	return trimmed, nil
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestID_UnmarshalJSON_Numeric(t *testing.T) {
+	var zone Zone
+	require.NoError(t, json.Unmarshal([]byte(`{"id":123,"name":"example.com"}`), &zone))
+
+	assert.Equal(t, "123", zone.ID.String())
+}
+
+func TestID_UnmarshalJSON_String(t *testing.T) {
+	var zone Zone
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"123","name":"example.com"}`), &zone))
+
+	assert.Equal(t, "123", zone.ID.String())
+}
+
+func TestID_UnmarshalJSON_Missing(t *testing.T) {
+	var record Record
+	err := json.Unmarshal([]byte(`{"id":"","name":"_acme-challenge"}`), &record)
+	require.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package hetznerhcloud
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// zoneSerializer, when interval is positive, serializes writes to the same
+// Hetzner Cloud zone and spaces consecutive writes to a given zone at least
+// interval apart; with a zero interval it does neither. This works around conflicts the zones endpoint returns when
+// many record writes on the same zone are submitted concurrently during bulk
+// SAN certificate issuance.
+type zoneSerializer struct {
+	interval time.Duration
+
+	mu         sync.Mutex
+	zoneLocks  map[string]*sync.Mutex
+	lastWrites map[string]time.Time
+}
+
+func newZoneSerializer(interval time.Duration) *zoneSerializer {
+	return &zoneSerializer{
+		interval:   interval,
+		zoneLocks:  make(map[string]*sync.Mutex),
+		lastWrites: make(map[string]time.Time),
+	}
+}
+
+// do runs write, which must itself be safe to call concurrently. When
+// interval is zero, writes are not serialized at all, preserving the
+// existing concurrency for callers who haven't opted in. When interval is
+// positive, writes to the same zoneID are serialized through zoneID's lock
+// and spaced at least interval apart.
+func (s *zoneSerializer) do(ctx context.Context, zoneID string, write func() error) error {
+	if s.interval <= 0 {
+		return write()
+	}
+
+	mu := s.lockFor(zoneID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := s.waitTurn(ctx, zoneID); err != nil {
+		return err
+	}
+
+	err := write()
+
+	s.mu.Lock()
+	s.lastWrites[zoneID] = time.Now()
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *zoneSerializer) lockFor(zoneID string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mu, ok := s.zoneLocks[zoneID]
+	if !ok {
+		mu = &sync.Mutex{}
+		s.zoneLocks[zoneID] = mu
+	}
+
+	return mu
+}
+
+func (s *zoneSerializer) waitTurn(ctx context.Context, zoneID string) error {
+	s.mu.Lock()
+	last, ok := s.lastWrites[zoneID]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	wait := s.interval - time.Since(last)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
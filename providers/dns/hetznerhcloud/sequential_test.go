@@ -0,0 +1,100 @@
+package hetznerhcloud
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZoneSerializer_SerializesSameZone(t *testing.T) {
+	s := newZoneSerializer(time.Millisecond)
+
+	var inFlight int32
+	var maxInFlight int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_ = s.do(context.Background(), "zone-1", func() error {
+				n := atomic.AddInt32(&inFlight, 1)
+				for {
+					old := atomic.LoadInt32(&maxInFlight)
+					if n <= old || atomic.CompareAndSwapInt32(&maxInFlight, old, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&inFlight, -1)
+				return nil
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, 1, maxInFlight)
+}
+
+func TestZoneSerializer_DisabledDoesNotSerialize(t *testing.T) {
+	s := newZoneSerializer(0)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go func() {
+		_ = s.do(context.Background(), "zone-1", func() error {
+			<-release
+			return nil
+		})
+	}()
+
+	// Give the first write a chance to start (and, if the bug regresses, to
+	// take the zone lock) before firing the second one.
+	time.Sleep(20 * time.Millisecond)
+
+	go func() {
+		_ = s.do(context.Background(), "zone-1", func() error {
+			close(started)
+			return nil
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("second write was blocked by the first with SequentialInterval disabled")
+	}
+
+	close(release)
+}
+
+func TestZoneSerializer_WaitsBetweenWrites(t *testing.T) {
+	s := newZoneSerializer(50 * time.Millisecond)
+
+	start := time.Now()
+
+	require.NoError(t, s.do(context.Background(), "zone-1", func() error { return nil }))
+	require.NoError(t, s.do(context.Background(), "zone-1", func() error { return nil }))
+
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestZoneSerializer_HonorsContextCancellation(t *testing.T) {
+	s := newZoneSerializer(time.Minute)
+
+	require.NoError(t, s.do(context.Background(), "zone-1", func() error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := s.do(ctx, "zone-1", func() error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+}